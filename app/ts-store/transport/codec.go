@@ -0,0 +1,135 @@
+/*
+Copyright 2022 Huawei Cloud Computing Technologies Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/encoding"
+	"github.com/openGemini/openGemini/lib/netstorage"
+	"github.com/openGemini/openGemini/lib/util"
+	"github.com/openGemini/openGemini/open_src/vm/protoparser/influx"
+)
+
+// decodedBatch is what every IngestDecoder produces, regardless of the wire
+// format it was parsed from.
+type decodedBatch struct {
+	db, rp      string
+	ptId        uint32
+	shard       uint64
+	consistency ConsistencyLevel
+	topics      []TopicDescriptor
+	rows        []influx.Row
+	binaryRows  []byte
+}
+
+// IngestDecoder turns a raw frame (type byte already stripped) into rows
+// plus the routing metadata needed to write them. Implementations are
+// looked up by the frame's leading type byte, so third parties can register
+// their own wire formats without touching ts-store.
+type IngestDecoder interface {
+	// Decode parses data into a decodedBatch. ww is passed through so
+	// implementations that can reuse its pooled row/tag/field buffers
+	// (as the built-in fast codec does) avoid an extra allocation; codecs
+	// that can't (Prometheus, OTLP) are free to build rows on the heap.
+	Decode(ww *WritePointsWork, data []byte) (decodedBatch, error)
+}
+
+var codecRegistry sync.Map // map[byte]IngestDecoder
+
+// RegisterIngestCodec associates an IngestDecoder with a frame type byte.
+// Registering the same type byte twice replaces the previous codec, which
+// lets a third party shadow a built-in decoder if it needs to.
+func RegisterIngestCodec(ty byte, dec IngestDecoder) {
+	codecRegistry.Store(ty, dec)
+}
+
+func lookupIngestCodec(ty byte) (IngestDecoder, error) {
+	v, ok := codecRegistry.Load(ty)
+	if !ok {
+		return nil, fmt.Errorf("no ingest codec registered for frame type %#x", ty)
+	}
+	return v.(IngestDecoder), nil
+}
+
+func init() {
+	// PackageTypeFast keeps the pre-quorum wire layout byte-for-byte (no
+	// consistency byte) so existing clients are unaffected; it always
+	// writes with ConsistencyAny, matching the old fire-and-forget-to-slaves
+	// behavior. Quorum-aware clients opt in by sending PackageTypeFastQuorum
+	// instead, which adds the single consistency byte.
+	RegisterIngestCodec(netstorage.PackageTypeFast, &fastCodec{withConsistency: false})
+	RegisterIngestCodec(netstorage.PackageTypeFastQuorum, &fastCodec{withConsistency: true})
+	RegisterIngestCodec(netstorage.PackageTypePromRemoteWrite, &promRemoteWriteCodec{})
+	RegisterIngestCodec(netstorage.PackageTypeOTLPMetrics, &otlpMetricsCodec{})
+}
+
+// decodeRoutingEnvelope parses the header every built-in codec shares:
+// length-prefixed db/rp names, the partition id and shard id, optionally
+// preceded by a consistency byte. withConsistency is false only for the
+// legacy PackageTypeFast layout, so old clients that never heard of quorum
+// writes keep decoding correctly; PackageTypeFastQuorum and the Prometheus
+// and OTLP codecs pass true.
+func decodeRoutingEnvelope(tail []byte, withConsistency bool) (rest []byte, db, rp string, ptId uint32, shard uint64, consistency ConsistencyLevel, err error) {
+	consistency = ConsistencyAny
+	if len(tail) < 1 {
+		err = errors.New("invalid points buffer")
+		return
+	}
+
+	if withConsistency {
+		consistency = ConsistencyLevel(tail[0])
+		tail = tail[1:]
+	}
+
+	if len(tail) < 1 {
+		err = errors.New("invalid points buffer")
+		return
+	}
+	l := int(tail[0])
+	if len(tail) < l+1 {
+		err = errors.New("no data for db name")
+		return
+	}
+	tail = tail[1:]
+	db = util.Bytes2str(tail[:l])
+	tail = tail[l:]
+
+	l = int(tail[0])
+	if len(tail) < l+1 {
+		err = errors.New("no data for rp name")
+		return
+	}
+	tail = tail[1:]
+	rp = util.Bytes2str(tail[:l])
+	tail = tail[l:]
+
+	if len(tail) < 12 {
+		err = errors.New("no data for points data")
+		return
+	}
+	ptId = encoding.UnmarshalUint32(tail)
+	tail = tail[4:]
+
+	shard = encoding.UnmarshalUint64(tail)
+	tail = tail[8:]
+
+	rest = tail
+	return
+}
@@ -0,0 +1,69 @@
+/*
+Copyright 2022 Huawei Cloud Computing Technologies Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"github.com/openGemini/openGemini/open_src/vm/protoparser/influx"
+)
+
+// fastCodec decodes the openGemini fast-marshal binary layout: db name, rp
+// name, ptId, shard, topic descriptor list, then FastUnmarshalMultiRows-
+// encoded rows. It is the only codec that reuses ww's pooled tag/field/row
+// buffers, since it is the hot path.
+//
+// withConsistency selects which of the two registered type bytes this
+// instance handles: PackageTypeFast (false) is the original layout with no
+// consistency byte, kept byte-for-byte compatible so existing clients are
+// unaffected; PackageTypeFastQuorum (true) adds the single consistency byte
+// ahead of the db name for clients that want tunable quorum writes.
+type fastCodec struct {
+	withConsistency bool
+}
+
+func (c fastCodec) Decode(ww *WritePointsWork, tail []byte) (decodedBatch, error) {
+	var batch decodedBatch
+
+	tail, db, rp, ptId, shard, consistency, err := decodeRoutingEnvelope(tail, c.withConsistency)
+	if err != nil {
+		return batch, err
+	}
+	batch.db, batch.rp, batch.ptId, batch.shard, batch.consistency = db, rp, ptId, shard, consistency
+
+	batch.topics, tail, err = decodeTopicDescriptors(tail)
+	if err != nil {
+		return batch, err
+	}
+
+	batch.binaryRows = tail
+
+	ww.rows = ww.rows[:0]
+	ww.tagpools = ww.tagpools[:0]
+	ww.fieldpools = ww.fieldpools[:0]
+	ww.indexKeypools = ww.indexKeypools[:0]
+	for i := range ww.indexOptionpools {
+		ww.indexOptionpools[i].IndexList = ww.indexOptionpools[i].IndexList[:0]
+	}
+	ww.indexOptionpools = ww.indexOptionpools[:0]
+	ww.rows, ww.tagpools, ww.fieldpools, ww.indexOptionpools, ww.indexKeypools, err =
+		influx.FastUnmarshalMultiRows(tail, ww.rows, ww.tagpools, ww.fieldpools, ww.indexOptionpools, ww.indexKeypools)
+	if err != nil {
+		return batch, err
+	}
+	batch.rows = ww.rows
+
+	return batch, nil
+}
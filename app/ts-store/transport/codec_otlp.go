@@ -0,0 +1,106 @@
+/*
+Copyright 2022 Huawei Cloud Computing Technologies Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"fmt"
+
+	"github.com/openGemini/openGemini/open_src/vm/protoparser/influx"
+	colmetricpb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	metricpb "go.opentelemetry.io/proto/otlp/metrics/v1"
+)
+
+// otlpMetricsCodec decodes an OTLP ExportMetricsServiceRequest, letting
+// ts-store act as a metrics collector sink without a separate gateway.
+// Only Gauge and Sum number data points are translated today; histograms,
+// summaries and exponential histograms return an error rather than being
+// silently dropped, so a caller sees exactly what isn't supported yet.
+type otlpMetricsCodec struct{}
+
+func (otlpMetricsCodec) Decode(ww *WritePointsWork, tail []byte) (decodedBatch, error) {
+	var batch decodedBatch
+
+	tail, db, rp, ptId, shard, consistency, err := decodeRoutingEnvelope(tail, true)
+	if err != nil {
+		return batch, err
+	}
+	batch.db, batch.rp, batch.ptId, batch.shard, batch.consistency = db, rp, ptId, shard, consistency
+
+	var req colmetricpb.ExportMetricsServiceRequest
+	if err := req.Unmarshal(tail); err != nil {
+		return batch, err
+	}
+
+	rows, err := rowsFromOTLPMetrics(&req)
+	if err != nil {
+		return batch, err
+	}
+	batch.rows = rows
+	return batch, nil
+}
+
+func rowsFromOTLPMetrics(req *colmetricpb.ExportMetricsServiceRequest) ([]influx.Row, error) {
+	var rows []influx.Row
+	for _, rm := range req.ResourceMetrics {
+		resourceTags := tagsFromOTLPAttributes(rm.Resource.GetAttributes())
+		for _, sm := range rm.ScopeMetrics {
+			for _, m := range sm.Metrics {
+				switch data := m.Data.(type) {
+				case *metricpb.Metric_Gauge:
+					rows = append(rows, rowsFromOTLPNumberPoints(m.Name, resourceTags, data.Gauge.DataPoints)...)
+				case *metricpb.Metric_Sum:
+					rows = append(rows, rowsFromOTLPNumberPoints(m.Name, resourceTags, data.Sum.DataPoints)...)
+				default:
+					return rows, fmt.Errorf("otlp metric %q: unsupported data type %T", m.Name, data)
+				}
+			}
+		}
+	}
+	return rows, nil
+}
+
+func rowsFromOTLPNumberPoints(name string, resourceTags []influx.Tag, points []*metricpb.NumberDataPoint) []influx.Row {
+	rows := make([]influx.Row, 0, len(points))
+	for _, pt := range points {
+		tags := append(append([]influx.Tag{}, resourceTags...), tagsFromOTLPAttributes(pt.Attributes)...)
+		var value float64
+		switch v := pt.Value.(type) {
+		case *metricpb.NumberDataPoint_AsDouble:
+			value = v.AsDouble
+		case *metricpb.NumberDataPoint_AsInt:
+			value = float64(v.AsInt)
+		}
+		rows = append(rows, influx.Row{
+			Name:      name,
+			Tags:      tags,
+			Timestamp: int64(pt.TimeUnixNano),
+			Fields: []influx.Field{
+				{Key: "value", NumValue: value, Type: influx.Field_Type_Float},
+			},
+		})
+	}
+	return rows
+}
+
+func tagsFromOTLPAttributes(attrs []*commonpb.KeyValue) []influx.Tag {
+	tags := make([]influx.Tag, 0, len(attrs))
+	for _, kv := range attrs {
+		tags = append(tags, influx.Tag{Key: kv.Key, Value: kv.Value.GetStringValue()})
+	}
+	return tags
+}
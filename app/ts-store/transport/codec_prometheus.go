@@ -0,0 +1,82 @@
+/*
+Copyright 2022 Huawei Cloud Computing Technologies Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"github.com/golang/snappy"
+	"github.com/openGemini/openGemini/open_src/vm/protoparser/influx"
+	"github.com/prometheus/prometheus/prompb"
+)
+
+// promRemoteWriteCodec decodes a Prometheus remote-write frame: the routing
+// envelope shared with the other built-in codecs, followed by a
+// snappy-framed prompb.WriteRequest. This lets openGemini act as a drop-in
+// remote-write endpoint without a separate proxy in front of it.
+type promRemoteWriteCodec struct{}
+
+func (promRemoteWriteCodec) Decode(ww *WritePointsWork, tail []byte) (decodedBatch, error) {
+	var batch decodedBatch
+
+	tail, db, rp, ptId, shard, consistency, err := decodeRoutingEnvelope(tail, true)
+	if err != nil {
+		return batch, err
+	}
+	batch.db, batch.rp, batch.ptId, batch.shard, batch.consistency = db, rp, ptId, shard, consistency
+
+	raw, err := snappy.Decode(nil, tail)
+	if err != nil {
+		return batch, err
+	}
+
+	var req prompb.WriteRequest
+	if err := req.Unmarshal(raw); err != nil {
+		return batch, err
+	}
+
+	batch.rows = rowsFromPromTimeSeries(req.Timeseries)
+	return batch, nil
+}
+
+// rowsFromPromTimeSeries flattens each Prometheus series into one
+// influx.Row per sample, using the reserved "__name__" label as the
+// measurement and every other label as a tag.
+func rowsFromPromTimeSeries(series []prompb.TimeSeries) []influx.Row {
+	var rows []influx.Row
+	for _, ts := range series {
+		name := "prom"
+		tags := make([]influx.Tag, 0, len(ts.Labels))
+		for _, lbl := range ts.Labels {
+			if lbl.Name == "__name__" {
+				name = lbl.Value
+				continue
+			}
+			tags = append(tags, influx.Tag{Key: lbl.Name, Value: lbl.Value})
+		}
+
+		for _, sample := range ts.Samples {
+			rows = append(rows, influx.Row{
+				Name:      name,
+				Tags:      tags,
+				Timestamp: sample.Timestamp,
+				Fields: []influx.Field{
+					{Key: "value", NumValue: sample.Value, Type: influx.Field_Type_Float},
+				},
+			})
+		}
+	}
+	return rows
+}
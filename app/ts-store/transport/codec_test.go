@@ -0,0 +1,41 @@
+/*
+Copyright 2022 Huawei Cloud Computing Technologies Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import "testing"
+
+func TestDecodeRoutingEnvelopeTruncatedDbNameErrorsInsteadOfPanicking(t *testing.T) {
+	// length byte claims 5 bytes of db name, but only 4 remain after it -
+	// the exact boundary the off-by-one bug in decodeRoutingEnvelope missed.
+	tail := []byte{5, 'a', 'b', 'c', 'd'}
+
+	_, _, _, _, _, _, err := decodeRoutingEnvelope(tail, false)
+	if err == nil {
+		t.Fatal("expected an error for a truncated db name, got nil")
+	}
+}
+
+func TestDecodeRoutingEnvelopeTruncatedRpNameErrorsInsteadOfPanicking(t *testing.T) {
+	// valid db name "ab", then an rp length byte claiming 3 bytes with only
+	// 2 remaining.
+	tail := []byte{2, 'a', 'b', 3, 'x', 'y'}
+
+	_, _, _, _, _, _, err := decodeRoutingEnvelope(tail, false)
+	if err == nil {
+		t.Fatal("expected an error for a truncated rp name, got nil")
+	}
+}
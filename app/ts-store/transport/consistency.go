@@ -0,0 +1,107 @@
+/*
+Copyright 2022 Huawei Cloud Computing Technologies Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"fmt"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/encoding"
+)
+
+// ConsistencyLevel controls how many replicas must acknowledge a write
+// before WritePoints reports success, analogous to the tunable consistency
+// levels in etcd/BanyanDB.
+type ConsistencyLevel byte
+
+const (
+	// ConsistencyAny is satisfied once the write has landed anywhere,
+	// including the hinted-handoff queue.
+	ConsistencyAny ConsistencyLevel = iota
+	// ConsistencyOne is satisfied once a single replica (local or remote)
+	// acknowledges the write.
+	ConsistencyOne
+	// ConsistencyQuorum is satisfied once a strict majority of replicas
+	// (including local) acknowledge the write.
+	ConsistencyQuorum
+	// ConsistencyAll requires every replica to acknowledge the write.
+	ConsistencyAll
+)
+
+func (c ConsistencyLevel) String() string {
+	switch c {
+	case ConsistencyAny:
+		return "ANY"
+	case ConsistencyOne:
+		return "ONE"
+	case ConsistencyQuorum:
+		return "QUORUM"
+	case ConsistencyAll:
+		return "ALL"
+	default:
+		return fmt.Sprintf("ConsistencyLevel(%d)", byte(c))
+	}
+}
+
+// quorumOf returns the number of acks (out of n total replicas, local
+// write included) required to satisfy c.
+func quorumOf(c ConsistencyLevel, n int) int {
+	switch c {
+	case ConsistencyAny:
+		return 0
+	case ConsistencyOne:
+		if n > 0 {
+			return 1
+		}
+		return 0
+	case ConsistencyAll:
+		return n
+	case ConsistencyQuorum:
+		fallthrough
+	default:
+		return n/2 + 1
+	}
+}
+
+// replicaStatus is the per-replica outcome carried back in the response
+// frame so netstorage can surface partial-write errors instead of a single
+// binary success/failure.
+type replicaStatus struct {
+	ReplicaID uint64
+	Err       error
+}
+
+// EncodeQuorumStatuses serializes the per-replica outcome of a quorum write
+// for the response frame: a uint32 count, then per status a uint64 replica
+// id, a byte ok flag, and (only when not ok) the length-prefixed error
+// string. The client-side netstorage layer decodes this to surface
+// partial-write errors instead of a single success/failure bit.
+func EncodeQuorumStatuses(statuses []replicaStatus) []byte {
+	buf := make([]byte, 0, 4+len(statuses)*16)
+	buf = encoding.MarshalUint32(buf, uint32(len(statuses)))
+	for _, st := range statuses {
+		buf = encoding.MarshalUint64(buf, st.ReplicaID)
+		if st.Err == nil {
+			buf = append(buf, 1)
+			continue
+		}
+		buf = append(buf, 0)
+		msg := st.Err.Error()
+		buf = encoding.MarshalUint32(buf, uint32(len(msg)))
+		buf = append(buf, msg...)
+	}
+	return buf
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2022 Huawei Cloud Computing Technologies Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestQuorumOf(t *testing.T) {
+	cases := []struct {
+		c    ConsistencyLevel
+		n    int
+		want int
+	}{
+		{ConsistencyAny, 3, 0},
+		{ConsistencyOne, 3, 1},
+		{ConsistencyOne, 0, 0},
+		{ConsistencyQuorum, 3, 2},
+		{ConsistencyQuorum, 4, 3},
+		{ConsistencyAll, 3, 3},
+	}
+	for _, tc := range cases {
+		if got := quorumOf(tc.c, tc.n); got != tc.want {
+			t.Errorf("quorumOf(%v, %d) = %d, want %d", tc.c, tc.n, got, tc.want)
+		}
+	}
+}
+
+func TestConsistencyLevelString(t *testing.T) {
+	if got := ConsistencyQuorum.String(); got != "QUORUM" {
+		t.Errorf("String() = %q, want QUORUM", got)
+	}
+	if got := ConsistencyLevel(99).String(); got == "" {
+		t.Errorf("String() for unknown level should not be empty")
+	}
+}
+
+func TestEncodeQuorumStatusesCountPrefix(t *testing.T) {
+	statuses := []replicaStatus{
+		{ReplicaID: 1, Err: nil},
+		{ReplicaID: 2, Err: errors.New("timeout")},
+	}
+	buf := EncodeQuorumStatuses(statuses)
+	if len(buf) == 0 {
+		t.Fatal("expected non-empty encoding")
+	}
+
+	count := int(buf[3]) | int(buf[2])<<8 | int(buf[1])<<16 | int(buf[0])<<24
+	if count != len(statuses) {
+		t.Fatalf("encoded count = %d, want %d", count, len(statuses))
+	}
+}
@@ -0,0 +1,263 @@
+/*
+Copyright 2022 Huawei Cloud Computing Technologies Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/encoding"
+	"github.com/openGemini/openGemini/lib/errno"
+	"github.com/openGemini/openGemini/lib/logger"
+	"go.uber.org/zap"
+)
+
+// hintedWrite is a write that couldn't be acked by replicaID in time and is
+// queued for later replay.
+type hintedWrite struct {
+	replicaID  uint64
+	db, rp     string
+	ptId       uint32
+	shard      uint64
+	binaryRows []byte
+}
+
+// hhQueue is the process-wide hinted handoff queue, populated once
+// Server.Run starts draining writes owed to lagging replicas.
+var hhQueue *hintedHandoffQueue
+
+// hintedHandoffQueue is a bounded, disk-spilling queue of writes owed to
+// replicas that missed the write's deadline. It is drained by a background
+// goroutine that replays entries with exponential backoff; once a replica
+// has caught up its hints are dropped.
+type hintedHandoffQueue struct {
+	mu       sync.Mutex
+	maxItems int
+	spillDir string
+	pending  []*hintedWrite
+	spilled  int
+
+	replay func(*hintedWrite) error
+
+	log *logger.Logger
+}
+
+// newHintedHandoffQueue builds a queue bounded to maxItems in-memory
+// entries; once exceeded, further writes spill to spillDir (one file per
+// entry) instead of being dropped.
+func newHintedHandoffQueue(maxItems int, spillDir string, replay func(*hintedWrite) error) *hintedHandoffQueue {
+	return &hintedHandoffQueue{
+		maxItems: maxItems,
+		spillDir: spillDir,
+		replay:   replay,
+		log:      logger.NewLogger(errno.ModuleStorageEngine),
+	}
+}
+
+func (q *hintedHandoffQueue) push(w *hintedWrite) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.pending) < q.maxItems {
+		q.pending = append(q.pending, w)
+		return
+	}
+	if err := q.spill(w); err != nil {
+		q.log.Error("hinted handoff queue full and spill failed, dropping write",
+			zap.Uint64("replicaId", w.replicaID), zap.String("db", w.db), zap.Error(err))
+		return
+	}
+	q.spilled++
+}
+
+func (q *hintedHandoffQueue) spill(w *hintedWrite) error {
+	if err := os.MkdirAll(q.spillDir, 0750); err != nil {
+		return err
+	}
+	name := filepath.Join(q.spillDir, hintedHandoffFileName(w))
+	return os.WriteFile(name, encodeHintedWrite(w), 0640)
+}
+
+// drain runs until stop is closed, periodically replaying queued writes.
+// Each failed attempt backs off exponentially up to a 1-minute cap.
+func (q *hintedHandoffQueue) drain(stop <-chan struct{}) {
+	backoff := time.Second
+	const maxBackoff = time.Minute
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-time.After(backoff):
+		}
+
+		if q.drainOnce() {
+			backoff = time.Second
+			continue
+		}
+		backoff *= 2
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
+
+// drainOnce replays everything currently queued, including writes already
+// spilled to disk from a previous overflow, and reports whether the queue
+// is now empty.
+func (q *hintedHandoffQueue) drainOnce() bool {
+	q.mu.Lock()
+	items := q.pending
+	q.pending = nil
+	q.mu.Unlock()
+
+	ok := true
+	var failed []*hintedWrite
+	for _, w := range items {
+		if err := q.replay(w); err != nil {
+			q.log.Error("hinted handoff replay failed, retrying later",
+				zap.Uint64("replicaId", w.replicaID), zap.Error(err))
+			failed = append(failed, w)
+			ok = false
+		}
+	}
+
+	if !q.drainSpilled() {
+		ok = false
+	}
+
+	if len(failed) > 0 {
+		q.mu.Lock()
+		q.pending = append(failed, q.pending...)
+		q.mu.Unlock()
+	}
+
+	return ok
+}
+
+// drainSpilled replays every write that previously overflowed to disk. A
+// file is only removed once its write has been successfully replayed, so a
+// crash mid-drain just leaves it to be retried on the next pass.
+func (q *hintedHandoffQueue) drainSpilled() bool {
+	entries, err := os.ReadDir(q.spillDir)
+	if err != nil {
+		// The spill directory is only created on the first overflow, so a
+		// missing directory just means nothing has spilled yet.
+		return true
+	}
+
+	ok := true
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		path := filepath.Join(q.spillDir, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			q.log.Error("failed to read spilled hinted handoff file", zap.String("path", path), zap.Error(err))
+			ok = false
+			continue
+		}
+		w, err := decodeHintedWrite(data)
+		if err != nil {
+			q.log.Error("failed to decode spilled hinted handoff file, dropping", zap.String("path", path), zap.Error(err))
+			_ = os.Remove(path)
+			continue
+		}
+		if err := q.replay(w); err != nil {
+			q.log.Error("hinted handoff spill replay failed, retrying later",
+				zap.Uint64("replicaId", w.replicaID), zap.Error(err))
+			ok = false
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			q.log.Error("failed to remove replayed hinted handoff spill file", zap.String("path", path), zap.Error(err))
+		}
+	}
+	return ok
+}
+
+// spillSeq guarantees spill file names are unique even when the same
+// db/rp/shard/replica overflows more than once, so a later spill never
+// clobbers an earlier one that hasn't been drained yet.
+var spillSeq uint64
+
+func hintedHandoffFileName(w *hintedWrite) string {
+	seq := atomic.AddUint64(&spillSeq, 1)
+	return fmt.Sprintf("%s-%s-%d-%d-%d-%d.hh", w.db, w.rp, w.shard, w.replicaID, time.Now().UnixNano(), seq)
+}
+
+// encodeHintedWrite serializes a hintedWrite into a self-describing binary
+// record so it can be read back by a later process after spilling to disk:
+// length-prefixed db/rp names, ptId, shard, replicaID, then the raw rows.
+func encodeHintedWrite(w *hintedWrite) []byte {
+	buf := make([]byte, 0, len(w.db)+len(w.rp)+len(w.binaryRows)+32)
+	buf = encoding.MarshalUint32(buf, uint32(len(w.db)))
+	buf = append(buf, w.db...)
+	buf = encoding.MarshalUint32(buf, uint32(len(w.rp)))
+	buf = append(buf, w.rp...)
+	buf = encoding.MarshalUint32(buf, w.ptId)
+	buf = encoding.MarshalUint64(buf, w.shard)
+	buf = encoding.MarshalUint64(buf, w.replicaID)
+	buf = append(buf, w.binaryRows...)
+	return buf
+}
+
+func decodeHintedWrite(data []byte) (*hintedWrite, error) {
+	w := &hintedWrite{}
+
+	data, db, err := readLengthPrefixed(data)
+	if err != nil {
+		return nil, fmt.Errorf("hinted handoff db name: %w", err)
+	}
+	w.db = string(db)
+
+	data, rp, err := readLengthPrefixed(data)
+	if err != nil {
+		return nil, fmt.Errorf("hinted handoff rp name: %w", err)
+	}
+	w.rp = string(rp)
+
+	if len(data) < 20 {
+		return nil, fmt.Errorf("hinted handoff record truncated")
+	}
+	w.ptId = encoding.UnmarshalUint32(data)
+	data = data[4:]
+	w.shard = encoding.UnmarshalUint64(data)
+	data = data[8:]
+	w.replicaID = encoding.UnmarshalUint64(data)
+	data = data[8:]
+
+	w.binaryRows = data
+	return w, nil
+}
+
+func readLengthPrefixed(data []byte) (rest, value []byte, err error) {
+	if len(data) < 4 {
+		return nil, nil, fmt.Errorf("missing length prefix")
+	}
+	l := encoding.UnmarshalUint32(data)
+	data = data[4:]
+	if uint32(len(data)) < l {
+		return nil, nil, fmt.Errorf("truncated value")
+	}
+	return data[l:], data[:l], nil
+}
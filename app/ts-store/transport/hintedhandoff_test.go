@@ -0,0 +1,62 @@
+/*
+Copyright 2022 Huawei Cloud Computing Technologies Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestEncodeDecodeHintedWriteRoundTrip(t *testing.T) {
+	w := &hintedWrite{
+		replicaID:  7,
+		db:         "mydb",
+		rp:         "autogen",
+		ptId:       3,
+		shard:      42,
+		binaryRows: []byte("some rows payload"),
+	}
+
+	got, err := decodeHintedWrite(encodeHintedWrite(w))
+	if err != nil {
+		t.Fatalf("decodeHintedWrite failed: %v", err)
+	}
+
+	if got.replicaID != w.replicaID || got.db != w.db || got.rp != w.rp ||
+		got.ptId != w.ptId || got.shard != w.shard || !bytes.Equal(got.binaryRows, w.binaryRows) {
+		t.Fatalf("decoded %+v, want %+v", got, w)
+	}
+}
+
+func TestDecodeHintedWriteTruncated(t *testing.T) {
+	if _, err := decodeHintedWrite([]byte{0, 0, 0, 1}); err == nil {
+		t.Fatal("expected error decoding truncated record")
+	}
+}
+
+func TestHintedHandoffFileNameUnique(t *testing.T) {
+	w := &hintedWrite{replicaID: 1, db: "db", rp: "rp", ptId: 1, shard: 1}
+
+	names := make(map[string]struct{})
+	for i := 0; i < 100; i++ {
+		name := hintedHandoffFileName(w)
+		if _, dup := names[name]; dup {
+			t.Fatalf("hintedHandoffFileName produced duplicate name %q", name)
+		}
+		names[name] = struct{}{}
+	}
+}
@@ -0,0 +1,159 @@
+/*
+Copyright 2022 Huawei Cloud Computing Technologies Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/golang/snappy"
+	"github.com/openGemini/openGemini/app/ts-store/storage"
+	"github.com/openGemini/openGemini/open_src/vm/protoparser/influx"
+	"github.com/prometheus/prometheus/prompb"
+	colmetricpb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+)
+
+// ingestStorage is the storage backend the HTTP ingest endpoints below
+// write through; set once by Server.Run alongside hhQueue, since neither
+// handler is a method on Server (they're registered on the debug mux
+// before any request-scoped state exists).
+var ingestStorage *storage.Storage
+
+// handlePromRemoteWrite implements POST /api/v1/write: the stock
+// Prometheus remote-write contract (snappy-framed prompb.WriteRequest, no
+// openGemini-specific routing envelope), so an unmodified Prometheus or
+// VictoriaMetrics agent can point remote_write straight at ts-store
+// instead of needing the binary fast-marshal wire protocol.
+func handlePromRemoteWrite(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	db, rp, ptId, shard, ok := writeTargetFromQuery(r)
+	if !ok {
+		http.Error(w, "missing or invalid db/rp/pt/shard query params", http.StatusBadRequest)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "failed to read body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	raw, err := snappy.Decode(nil, body)
+	if err != nil {
+		http.Error(w, "invalid snappy frame: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	var req prompb.WriteRequest
+	if err := req.Unmarshal(raw); err != nil {
+		http.Error(w, "invalid write request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	rows := rowsFromPromTimeSeries(req.Timeseries)
+	if err := writeHTTPRows(db, rp, ptId, shard, int64(len(body)), rows); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleOTLPMetrics implements POST /v1/metrics: the OTLP/HTTP metrics
+// export contract, so an OpenTelemetry Collector's otlphttp exporter can
+// point straight at ts-store without the routing envelope the binary OTLP
+// codec requires.
+func handleOTLPMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	db, rp, ptId, shard, ok := writeTargetFromQuery(r)
+	if !ok {
+		http.Error(w, "missing or invalid db/rp/pt/shard query params", http.StatusBadRequest)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "failed to read body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	var req colmetricpb.ExportMetricsServiceRequest
+	if err := req.Unmarshal(body); err != nil {
+		http.Error(w, "invalid metrics request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	rows, err := rowsFromOTLPMetrics(&req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := writeHTTPRows(db, rp, ptId, shard, int64(len(body)), rows); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeTargetFromQuery reads the db/rp/pt/shard routing fields the binary
+// protocol gets from its envelope out of the request's query string
+// instead, since an unmodified Prometheus/OTLP client has no notion of
+// openGemini's internal routing.
+func writeTargetFromQuery(r *http.Request) (db, rp string, ptId uint32, shard uint64, ok bool) {
+	q := r.URL.Query()
+	db = q.Get("db")
+	if db == "" {
+		return "", "", 0, 0, false
+	}
+	rp = q.Get("rp")
+
+	if v := q.Get("pt"); v != "" {
+		n, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			return "", "", 0, 0, false
+		}
+		ptId = uint32(n)
+	}
+	if v := q.Get("shard"); v != "" {
+		n, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return "", "", 0, 0, false
+		}
+		shard = n
+	}
+	return db, rp, ptId, shard, true
+}
+
+// writeHTTPRows admits and writes rows decoded by an HTTP ingest endpoint
+// through the same admission gate and local/quorum write path as the
+// binary wire protocol, using ConsistencyQuorum since HTTP clients have no
+// way to request a specific level.
+func writeHTTPRows(db, rp string, ptId uint32, shard uint64, reqBytes int64, rows []influx.Row) error {
+	if err := AcquireWriteSlot(reqBytes, writeAdmissionTimeout); err != nil {
+		return err
+	}
+	defer ReleaseWriteSlot(reqBytes)
+
+	ww := GetWritePointsWork()
+	defer PutWritePointsWork(ww)
+	ww.storage = ingestStorage
+
+	return ww.WriteDecodedRows(db, rp, ptId, shard, ConsistencyQuorum, rows)
+}
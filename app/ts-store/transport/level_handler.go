@@ -0,0 +1,64 @@
+/*
+Copyright 2022 Huawei Cloud Computing Technologies Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/openGemini/openGemini/lib/logger"
+	"go.uber.org/zap/zapcore"
+)
+
+// levelRequest is the body PUT /debug/log-level accepts. Module is optional;
+// when empty the process-wide level is changed, otherwise only the named
+// module (one of errno.Module*) is overridden.
+type levelRequest struct {
+	Module string `json:"module"`
+	Level  string `json:"level"`
+}
+
+// handleSetLevel lets an operator curl the process (or a single module's)
+// log level up or down without a restart, e.g.:
+//
+//	curl -XPUT localhost:8400/debug/log-level -d '{"level":"debug"}'
+//	curl -XPUT localhost:8400/debug/log-level -d '{"module":"write","level":"error"}'
+func handleSetLevel(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req levelRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var lvl zapcore.Level
+	if err := lvl.UnmarshalText([]byte(req.Level)); err != nil {
+		http.Error(w, "invalid level: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.Module == "" {
+		logger.SetLevel(lvl)
+	} else {
+		logger.SetModuleLevel(req.Module, lvl)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
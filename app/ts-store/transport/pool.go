@@ -0,0 +1,159 @@
+/*
+Copyright 2022 Huawei Cloud Computing Technologies Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/openGemini/openGemini/lib/config"
+	"github.com/openGemini/openGemini/lib/errno"
+	"github.com/openGemini/openGemini/lib/statisticsPusher/statistics"
+)
+
+// writeAdmission bounds how many writes, and how many bytes of them, may be
+// in flight at once. On the binary ingest path a write is admitted inside
+// WritePoints/WriteStreamPoints, after its frame has already been read into
+// reqBuf and before it is decoded and written; admission does not hold back
+// the reading of that frame or the allocation of its WritePointsWork.
+type writeAdmission struct {
+	slots chan struct{}
+
+	bytesInUse int64
+	bytesLimit int64
+
+	inflight int64
+	queued   int64
+	rejected int64
+
+	waitNsTotal int64
+	waitCount   int64
+}
+
+var (
+	admission   *writeAdmission
+	admissionMu sync.Mutex
+)
+
+// initAdmission (re)builds the process-wide write admission pool from the
+// current Common config. It must run after config.SetCommon has applied the
+// parsed config file, so Server.Run calls it the same way it rebuilds
+// hhQueue: fresh, from current config, rather than once at package-init time
+// when MaxInflightWrites/MaxInflightBytes are still the hardcoded defaults.
+func initAdmission() {
+	admissionMu.Lock()
+	defer admissionMu.Unlock()
+	admission = newWriteAdmission(config.GetCommon().MaxInflightWrites, config.GetCommon().MaxInflightBytes)
+}
+
+// getAdmission returns the process-wide admission pool, lazily building it
+// from the current config if Server.Run hasn't done so yet.
+func getAdmission() *writeAdmission {
+	admissionMu.Lock()
+	defer admissionMu.Unlock()
+	if admission == nil {
+		admission = newWriteAdmission(config.GetCommon().MaxInflightWrites, config.GetCommon().MaxInflightBytes)
+	}
+	return admission
+}
+
+func newWriteAdmission(maxInflightWrites int, maxInflightBytes int64) *writeAdmission {
+	if maxInflightWrites <= 0 {
+		maxInflightWrites = 1
+	}
+	return &writeAdmission{
+		slots:      make(chan struct{}, maxInflightWrites),
+		bytesLimit: maxInflightBytes,
+	}
+}
+
+// AcquireWriteSlot admits one write of reqBytes, blocking until a slot and
+// enough byte budget are free or timeout elapses. WritePoints,
+// WriteStreamPoints and writeHTTPRows call it, and call ReleaseWriteSlot
+// once the write (success or failure) is done.
+func AcquireWriteSlot(reqBytes int64, timeout time.Duration) error {
+	return getAdmission().acquire(reqBytes, timeout)
+}
+
+// ReleaseWriteSlot returns the slot reserved by the matching AcquireWriteSlot.
+func ReleaseWriteSlot(reqBytes int64) {
+	getAdmission().release(reqBytes)
+}
+
+func (a *writeAdmission) acquire(reqBytes int64, timeout time.Duration) error {
+	atomic.AddInt64(&a.queued, 1)
+	defer atomic.AddInt64(&a.queued, -1)
+
+	start := time.Now()
+	deadline := time.After(timeout)
+
+	select {
+	case a.slots <- struct{}{}:
+	case <-deadline:
+		atomic.AddInt64(&a.rejected, 1)
+		return errno.NewError(errno.ErrIngestOverloaded)
+	}
+
+	if !a.reserveBytes(reqBytes, deadline) {
+		<-a.slots
+		atomic.AddInt64(&a.rejected, 1)
+		return errno.NewError(errno.ErrIngestOverloaded)
+	}
+
+	atomic.AddInt64(&a.inflight, 1)
+	atomic.AddInt64(&a.waitNsTotal, time.Since(start).Nanoseconds())
+	atomic.AddInt64(&a.waitCount, 1)
+	a.publishStats()
+	return nil
+}
+
+func (a *writeAdmission) reserveBytes(reqBytes int64, deadline <-chan time.Time) bool {
+	if a.bytesLimit <= 0 {
+		return true
+	}
+	for {
+		if atomic.AddInt64(&a.bytesInUse, reqBytes) <= a.bytesLimit {
+			return true
+		}
+		atomic.AddInt64(&a.bytesInUse, -reqBytes)
+
+		select {
+		case <-time.After(time.Millisecond):
+		case <-deadline:
+			return false
+		}
+	}
+}
+
+func (a *writeAdmission) release(reqBytes int64) {
+	<-a.slots
+	if a.bytesLimit > 0 {
+		atomic.AddInt64(&a.bytesInUse, -reqBytes)
+	}
+	atomic.AddInt64(&a.inflight, -1)
+	a.publishStats()
+}
+
+func (a *writeAdmission) publishStats() {
+	statistics.PerfStat.WriteInflight = atomic.LoadInt64(&a.inflight)
+	statistics.PerfStat.WriteQueued = atomic.LoadInt64(&a.queued)
+	statistics.PerfStat.WriteRejected = atomic.LoadInt64(&a.rejected)
+	if n := atomic.LoadInt64(&a.waitCount); n > 0 {
+		statistics.PerfStat.WriteAvgWaitNs = atomic.LoadInt64(&a.waitNsTotal) / n
+	}
+}
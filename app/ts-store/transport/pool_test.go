@@ -0,0 +1,65 @@
+/*
+Copyright 2022 Huawei Cloud Computing Technologies Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWriteAdmissionAcquireRelease(t *testing.T) {
+	a := newWriteAdmission(1, 0)
+
+	if err := a.acquire(0, time.Second); err != nil {
+		t.Fatalf("acquire on an empty pool should succeed: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- a.acquire(0, 50*time.Millisecond)
+	}()
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatal("expected the second acquire to time out while the only slot is held")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("second acquire did not return before the test timeout")
+	}
+
+	a.release(0)
+	if err := a.acquire(0, time.Second); err != nil {
+		t.Fatalf("acquire should succeed once the slot is released: %v", err)
+	}
+}
+
+func TestWriteAdmissionByteBudget(t *testing.T) {
+	a := newWriteAdmission(4, 100)
+
+	if err := a.acquire(80, time.Second); err != nil {
+		t.Fatalf("acquire within the byte budget should succeed: %v", err)
+	}
+	if err := a.acquire(80, 50*time.Millisecond); err == nil {
+		t.Fatal("expected acquire to fail once the byte budget is exhausted")
+	}
+
+	a.release(80)
+	if err := a.acquire(80, time.Second); err != nil {
+		t.Fatalf("acquire should succeed once bytes are released: %v", err)
+	}
+}
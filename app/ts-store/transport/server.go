@@ -19,12 +19,11 @@ package transport
 import (
 	"errors"
 	"fmt"
+	"net/http"
 	"sync"
 	"sync/atomic"
 	"time"
 
-	"github.com/VictoriaMetrics/VictoriaMetrics/lib/encoding"
-	"github.com/VictoriaMetrics/VictoriaMetrics/lib/fasttime"
 	"github.com/openGemini/openGemini/app/ts-store/storage"
 	"github.com/openGemini/openGemini/app/ts-store/stream"
 	"github.com/openGemini/openGemini/lib/bufferpool"
@@ -33,7 +32,6 @@ import (
 	"github.com/openGemini/openGemini/lib/logger"
 	"github.com/openGemini/openGemini/lib/netstorage"
 	"github.com/openGemini/openGemini/lib/statisticsPusher/statistics"
-	"github.com/openGemini/openGemini/lib/util"
 	"github.com/openGemini/openGemini/open_src/vm/protoparser/influx"
 	"go.uber.org/zap"
 )
@@ -46,6 +44,11 @@ type Server struct {
 
 	selectServer *SelectServer
 	insertServer *InsertServer
+
+	// debugServer exposes operator-facing debug endpoints, e.g.
+	// PUT /debug/log-level. Nil (and never started) when
+	// config.GetCommon().DebugListenAddr is unset.
+	debugServer *http.Server
 }
 
 // NewServer returns new Server.
@@ -73,6 +76,16 @@ func (s *Server) Open() error {
 }
 
 func (s *Server) Run(store *storage.Storage, stream stream.Engine) {
+	hhQueue = newHintedHandoffQueue(config.GetCommon().HintedHandoffQueueMaxItems, config.GetCommon().HintedHandoffQueueDir,
+		func(w *hintedWrite) error {
+			return store.WriteRowsToReplica(w.replicaID, w.db, w.rp, w.ptId, w.shard, nil, w.binaryRows)
+		})
+	go hhQueue.drain(s.closed)
+
+	initAdmission()
+	ingestStorage = store
+	s.runDebugServer()
+
 	go s.insertServer.Run(store, stream)
 	//TODO stream support query
 	go s.selectServer.Run(store)
@@ -81,6 +94,30 @@ func (s *Server) Run(store *storage.Storage, stream stream.Engine) {
 	}
 }
 
+// runDebugServer starts the operator debug HTTP endpoints in the
+// background if config.GetCommon().DebugListenAddr is set. It's separate
+// from insertServer/selectServer since it's plaintext HTTP rather than the
+// binary ingest/select wire protocol.
+func (s *Server) runDebugServer() {
+	addr := config.GetCommon().DebugListenAddr
+	if addr == "" {
+		return
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/log-level", handleSetLevel)
+	mux.HandleFunc(topicStreamPrefix, handleTopicStream)
+	mux.HandleFunc("/api/v1/write", handlePromRemoteWrite)
+	mux.HandleFunc("/v1/metrics", handleOTLPMetrics)
+	s.debugServer = &http.Server{Addr: addr, Handler: mux}
+
+	go func() {
+		if err := s.debugServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			s.log.Error("debug server stopped unexpectedly", zap.Error(err))
+		}
+	}()
+}
+
 func (s *Server) setIsStopping() {
 	select {
 	case <-s.closed:
@@ -89,6 +126,9 @@ func (s *Server) setIsStopping() {
 	}
 }
 
+// GetWritePointsWork fetches a WritePointsWork from the pool. The pool itself
+// stays unbounded; on the binary ingest path the write is admitted later, by
+// WritePoints/WriteStreamPoints, and writeHTTPRows admits before calling this.
 func GetWritePointsWork() *WritePointsWork {
 	v := writePointsWorkPool.Get()
 	if v == nil {
@@ -117,11 +157,31 @@ type WritePointsWork struct {
 	fieldpools       []influx.Field
 	indexKeypools    []byte
 	indexOptionpools []influx.IndexOption
-	lastResetTime    uint64
+	// oversizedStreak counts consecutive Put calls where reqBuf/rows sat
+	// oversized relative to their live contents; once it crosses
+	// oversizedShrinkThreshold the buffers are proactively reclaimed
+	// instead of waiting on a fixed wall-clock timer.
+	oversizedStreak int
+
+	// quorumStatuses holds the per-replica outcome of the most recent
+	// writeQuorum call, so the caller serializing the response frame can
+	// surface partial-write errors instead of a single success/failure bit.
+	quorumStatuses []replicaStatus
 
 	logger *logger.Logger
 }
 
+// QuorumStatuses returns the per-replica outcomes of the last writeQuorum
+// call, for the InsertServer response path to encode via
+// EncodeQuorumStatuses.
+func (ww *WritePointsWork) QuorumStatuses() []replicaStatus {
+	return ww.quorumStatuses
+}
+
+// oversizedShrinkThreshold is how many consecutive idle Puts a
+// WritePointsWork may sit oversized for before its buffers are reclaimed.
+const oversizedShrinkThreshold = 3
+
 func (ww *WritePointsWork) GetRows() []influx.Row {
 	return ww.rows
 }
@@ -135,10 +195,15 @@ func (ww *WritePointsWork) PutWritePointsWork() {
 }
 
 func (ww *WritePointsWork) reset() {
-	if (len(ww.reqBuf)*4 > cap(ww.reqBuf) || len(ww.rows)*4 > cap(ww.rows)) && fasttime.UnixTimestamp()-ww.lastResetTime > 10 {
+	if cap(ww.reqBuf) > len(ww.reqBuf)*4 || cap(ww.rows) > len(ww.rows)*4 {
+		ww.oversizedStreak++
+	} else {
+		ww.oversizedStreak = 0
+	}
+	if ww.oversizedStreak >= oversizedShrinkThreshold {
 		ww.reqBuf = nil
 		ww.rows = nil
-		ww.lastResetTime = fasttime.UnixTimestamp()
+		ww.oversizedStreak = 0
 	}
 
 	ww.tagpools = ww.tagpools[:0]
@@ -150,92 +215,40 @@ func (ww *WritePointsWork) reset() {
 	ww.rows = ww.rows[:0]
 	ww.reqBuf = ww.reqBuf[:0]
 	ww.streamVars = ww.streamVars[:0]
+	ww.quorumStatuses = nil
 }
 
-func (ww *WritePointsWork) decodePoints() (db string, rp string, ptId uint32, shard uint64, streamShardIdList []uint64, binaryRows []byte, err error) {
-	tail := ww.reqBuf
-
+func (ww *WritePointsWork) decodePoints() (db string, rp string, ptId uint32, shard uint64, consistency ConsistencyLevel, topics []TopicDescriptor, binaryRows []byte, err error) {
 	start := time.Now()
 
-	if len(tail) < 2 {
+	tail := ww.reqBuf
+	if len(tail) < 1 {
 		err = errors.New("invalid points buffer")
 		ww.logger.Error(err.Error())
 		return
 	}
 	ty := tail[0]
-	if ty != netstorage.PackageTypeFast {
-		err = errors.New("not a fast marshal points package")
-		ww.logger.Error(err.Error())
-		return
-	}
-	tail = tail[1:]
-
-	l := int(tail[0])
-	if len(tail) < l {
-		err = errors.New("no data for db name")
-		ww.logger.Error(err.Error())
-		return
-	}
-	tail = tail[1:]
-	db = util.Bytes2str(tail[:l])
-	tail = tail[l:]
-
-	l = int(tail[0])
-	if len(tail) < l {
-		err = errors.New("no data for rp name")
-		ww.logger.Error(err.Error())
-		return
-	}
-	tail = tail[1:]
-	rp = util.Bytes2str(tail[:l])
-
-	tail = tail[l:]
-
-	if len(tail) < 16 {
-		err = errors.New("no data for points data")
-		ww.logger.Error(err.Error())
-		return
-	}
-	ptId = encoding.UnmarshalUint32(tail)
-	tail = tail[4:]
 
-	shard = encoding.UnmarshalUint64(tail)
-	tail = tail[8:]
-
-	sdLen := encoding.UnmarshalUint32(tail)
-	tail = tail[4:]
-
-	streamShardIdList = make([]uint64, sdLen)
-	tail, err = encoding.UnmarshalVarUint64s(streamShardIdList, tail)
+	codec, err := lookupIngestCodec(ty)
 	if err != nil {
 		ww.logger.Error(err.Error())
 		return
 	}
 
-	binaryRows = tail
-
-	ww.rows = ww.rows[:0]
-	ww.tagpools = ww.tagpools[:0]
-	ww.fieldpools = ww.fieldpools[:0]
-	ww.indexKeypools = ww.indexKeypools[:0]
-	for i := range ww.indexOptionpools {
-		ww.indexOptionpools[i].IndexList = ww.indexOptionpools[i].IndexList[:0]
-	}
-	ww.indexOptionpools = ww.indexOptionpools[:0]
-	ww.rows, ww.tagpools, ww.fieldpools, ww.indexOptionpools, ww.indexKeypools, err =
-		influx.FastUnmarshalMultiRows(tail, ww.rows, ww.tagpools, ww.fieldpools, ww.indexOptionpools, ww.indexKeypools)
+	batch, err := codec.Decode(ww, tail[1:])
 	if err != nil {
-		ww.logger.Error("unmarshal rows failed", zap.String("db", db),
-			zap.String("rp", rp), zap.Uint32("ptId", ptId), zap.Uint64("shardId", shard), zap.Error(err))
+		ww.logger.Error(err.Error())
 		return
 	}
+	db, rp, ptId, shard, consistency, topics, binaryRows = batch.db, batch.rp, batch.ptId, batch.shard, batch.consistency, batch.topics, batch.binaryRows
+	ww.rows = batch.rows
 
-	if len(streamShardIdList) > 0 {
+	if len(topics) > 0 {
 		// set stream vars into the rows
 		if len(ww.rows) != len(ww.streamVars) {
 			errStr := "unmarshal rows failed, the num of the rows is not equal to the stream vars"
 			ww.logger.Error(errStr, zap.String("db", db),
-				zap.String("rp", rp), zap.Uint32("ptId", ptId), zap.Uint64("shardId", shard), zap.Error(err))
+				zap.String("rp", rp), zap.Uint32("ptId", ptId), zap.Uint64("shardId", shard))
 			err = errors.New(errStr)
 			return
 		}
@@ -249,8 +262,19 @@ func (ww *WritePointsWork) decodePoints() (db string, rp string, ptId uint32, sh
 	return
 }
 
+// writeAdmissionTimeout bounds how long WritePoints/WriteStreamPoints wait
+// for an admission slot before rejecting the write outright; it mirrors
+// ReplicaWriteTimeout since both are bounding the same request's latency
+// budget.
+const writeAdmissionTimeout = 5 * time.Second
+
 func (ww *WritePointsWork) WritePoints() error {
-	db, rp, ptId, shard, _, binaryRows, err := ww.decodePoints()
+	if err := AcquireWriteSlot(int64(len(ww.reqBuf)), writeAdmissionTimeout); err != nil {
+		return err
+	}
+	defer ReleaseWriteSlot(int64(len(ww.reqBuf)))
+
+	db, rp, ptId, shard, consistency, _, binaryRows, err := ww.decodePoints()
 	if err != nil {
 		err = errno.NewError(errno.ErrUnmarshalPoints, err)
 		ww.logger.Error("unmarshal rows failed", zap.String("db", db),
@@ -260,18 +284,164 @@ func (ww *WritePointsWork) WritePoints() error {
 	if err = ww.storage.WriteRows(db, rp, ptId, shard, ww.rows, binaryRows); err != nil {
 		ww.logger.Error("write rows failed", zap.String("db", db),
 			zap.String("rp", rp), zap.Uint32("ptId", ptId), zap.Uint64("shardId", shard), zap.Error(err))
+		return err
+	}
+
+	if config.IsReplication() {
+		return ww.writeQuorum(consistency, db, rp, ptId, shard, binaryRows)
+	}
+
+	return nil
+}
+
+// WriteDecodedRows writes rows that were decoded by an HTTP ingest endpoint
+// (Prometheus remote-write, OTLP metrics) rather than one of the binary
+// wire codecs, through the same local-write-then-quorum-replicate path as
+// WritePoints. There is no binaryRows to forward to replicas or hinted
+// handoff since the HTTP body never contained the fast-marshal encoding;
+// replicas re-derive it from rows via storage.WriteRows/WriteRowsToReplica.
+func (ww *WritePointsWork) WriteDecodedRows(db, rp string, ptId uint32, shard uint64, consistency ConsistencyLevel, rows []influx.Row) error {
+	ww.rows = rows
+	if err := ww.storage.WriteRows(db, rp, ptId, shard, ww.rows, nil); err != nil {
+		ww.logger.Error("write rows failed", zap.String("db", db),
+			zap.String("rp", rp), zap.Uint32("ptId", ptId), zap.Uint64("shardId", shard), zap.Error(err))
+		return err
+	}
+
+	if config.IsReplication() {
+		return ww.writeQuorum(consistency, db, rp, ptId, shard, nil)
+	}
+	return nil
+}
+
+// writeQuorum fans the write out to every replica in parallel, waits up to
+// config.GetCommon().ReplicaWriteTimeout for acks and returns once
+// consistency's quorum has been met. Replicas that miss the deadline are
+// queued for hinted handoff instead of failing the whole write.
+func (ww *WritePointsWork) writeQuorum(consistency ConsistencyLevel, db, rp string, ptId uint32, shard uint64, binaryRows []byte) error {
+	replicas := ww.storage.Replicas(db, rp, ptId)
+	if len(replicas) == 0 {
+		return nil
+	}
+
+	need := quorumOf(consistency, len(replicas)+1) - 1 // local write already counted as one ack
+	if need < 0 {
+		need = 0
+	}
+
+	results := make(chan replicaStatus, len(replicas))
+	for _, replicaID := range replicas {
+		replicaID := replicaID
+		go func() {
+			err := ww.storage.WriteRowsToReplica(replicaID, db, rp, ptId, shard, ww.rows, binaryRows)
+			results <- replicaStatus{ReplicaID: replicaID, Err: err}
+		}()
+	}
+
+	deadline := time.After(config.GetCommon().ReplicaWriteTimeout)
+	acked := 0
+	var statuses []replicaStatus
+	if need == 0 {
+		// The local write alone satisfies consistency: don't block on
+		// replicas, but still collect their results in the background.
+		ww.drainRemainingReplicas(results, replicas, db, rp, ptId, shard, binaryRows)
+		return ww.quorumResult(consistency, acked, need, statuses)
+	}
+	for i := 0; i < len(replicas); i++ {
+		select {
+		case st := <-results:
+			statuses = append(statuses, st)
+			if st.Err == nil {
+				acked++
+			} else {
+				ww.queueHintedHandoff(st.ReplicaID, db, rp, ptId, shard, binaryRows)
+			}
+			if acked >= need {
+				// Quorum satisfied: return to the caller now instead of
+				// blocking on slower replicas, but keep draining their
+				// results in the background so a later failure still
+				// lands in hinted handoff.
+				ww.drainRemainingReplicas(results, pendingReplicas(replicas, statuses), db, rp, ptId, shard, binaryRows)
+				return ww.quorumResult(consistency, acked, need, statuses)
+			}
+		case <-deadline:
+			for _, replicaID := range pendingReplicas(replicas, statuses) {
+				ww.queueHintedHandoff(replicaID, db, rp, ptId, shard, binaryRows)
+			}
+			return ww.quorumResult(consistency, acked, need, statuses)
+		}
+	}
+
+	return ww.quorumResult(consistency, acked, need, statuses)
+}
+
+// drainRemainingReplicas keeps collecting acks for replicas whose result
+// hadn't arrived yet when quorum was already satisfied, so a replica that
+// eventually errors still gets queued for hinted handoff instead of being
+// silently forgotten.
+func (ww *WritePointsWork) drainRemainingReplicas(results <-chan replicaStatus, pending []uint64, db, rp string, ptId uint32, shard uint64, binaryRows []byte) {
+	if len(pending) == 0 {
+		return
 	}
+	go func() {
+		remaining := len(pending)
+		timeout := time.After(config.GetCommon().ReplicaWriteTimeout)
+		for remaining > 0 {
+			select {
+			case st := <-results:
+				remaining--
+				if st.Err != nil {
+					ww.queueHintedHandoff(st.ReplicaID, db, rp, ptId, shard, binaryRows)
+				}
+			case <-timeout:
+				for _, replicaID := range pending {
+					ww.queueHintedHandoff(replicaID, db, rp, ptId, shard, binaryRows)
+				}
+				return
+			}
+		}
+	}()
+}
 
-	if err == nil && config.IsReplication() {
-		err = ww.storage.WriteRowsToSlave(ww.rows, db, rp, ptId, shard)
+func (ww *WritePointsWork) quorumResult(consistency ConsistencyLevel, acked, need int, statuses []replicaStatus) error {
+	ww.quorumStatuses = statuses
+	if acked >= need {
+		return nil
 	}
+	ww.logger.Error("quorum not reached", zap.String("consistency", consistency.String()),
+		zap.Int("acked", acked), zap.Int("need", need))
+	return errno.NewError(errno.ErrQuorumNotReached)
+}
+
+func (ww *WritePointsWork) queueHintedHandoff(replicaID uint64, db, rp string, ptId uint32, shard uint64, binaryRows []byte) {
+	if hhQueue == nil {
+		return
+	}
+	hhQueue.push(&hintedWrite{replicaID: replicaID, db: db, rp: rp, ptId: ptId, shard: shard, binaryRows: binaryRows})
+}
 
-	return err
+func pendingReplicas(all []uint64, statuses []replicaStatus) []uint64 {
+	done := make(map[uint64]struct{}, len(statuses))
+	for _, st := range statuses {
+		done[st.ReplicaID] = struct{}{}
+	}
+	var pending []uint64
+	for _, id := range all {
+		if _, ok := done[id]; !ok {
+			pending = append(pending, id)
+		}
+	}
+	return pending
 }
 
 func (ww *WritePointsWork) WriteStreamPoints() (error, bool) {
 	var inUse bool
-	db, rp, ptId, shard, streamShardIdList, binaryRows, err := ww.decodePoints()
+	if err := AcquireWriteSlot(int64(len(ww.reqBuf)), writeAdmissionTimeout); err != nil {
+		return err, inUse
+	}
+	defer ReleaseWriteSlot(int64(len(ww.reqBuf)))
+
+	db, rp, ptId, shard, _, topics, binaryRows, err := ww.decodePoints()
 	if err != nil {
 		err = errno.NewError(errno.ErrUnmarshalPoints, err)
 		ww.logger.Error("unmarshal rows failed", zap.String("db", db),
@@ -282,29 +452,59 @@ func (ww *WritePointsWork) WriteStreamPoints() (error, bool) {
 		ww.logger.Error("write rows failed", zap.String("db", db),
 			zap.String("rp", rp), zap.Uint32("ptId", ptId), zap.Uint64("shardId", shard), zap.Error(err))
 	}
-	if ww.stream == nil || len(streamShardIdList) == 0 {
+	if ww.stream == nil || len(topics) == 0 {
 		return err, inUse
 	}
 
-	streamIdDstShardIdMap := make(map[uint64]uint64)
-	if len(streamShardIdList)%2 != 0 {
-		err = errno.NewError(errno.ErrUnmarshalPoints, err)
-		return err, inUse
-	}
-	for i := 0; i < len(streamShardIdList); i += 2 {
-		streamIdDstShardIdMap[streamShardIdList[i]] = streamShardIdList[i+1]
-	}
-	if err == nil && len(streamShardIdList) > 0 {
-		ww.stream.WriteRows(db, rp, ptId, shard, streamIdDstShardIdMap, ww)
+	if err == nil {
+		ww.fanOutToTopics(db, rp, ptId, shard, topics)
 		inUse = true
 	}
 	return err, inUse
 }
 
+// fanOutToTopics resolves each row's destination shard per topic from the
+// topic routing table, groups rows by (topic, shard) and hands each group
+// to stream.WriteRows, then publishes the batch to any SubscribeTopic
+// tailers once it's been committed.
+func (ww *WritePointsWork) fanOutToTopics(db, rp string, ptId uint32, shard uint64, topics []TopicDescriptor) {
+	for _, topic := range topics {
+		byShard := make(map[uint64][]influx.Row)
+		for i := range ww.rows {
+			dst, ok := resolveShard(topic.TopicId, topic.PartitionKeyFields, &ww.rows[i])
+			if !ok {
+				ww.logger.Error("no shard route for topic, dropping row", zap.Uint64("topicId", topic.TopicId))
+				continue
+			}
+			byShard[dst] = append(byShard[dst], cloneRowForSubscribers(ww.rows[i]))
+		}
+
+		for dstShard, rows := range byShard {
+			streamIdDstShardIdMap := map[uint64]uint64{topic.TopicId: dstShard}
+			ww.stream.WriteRows(db, rp, ptId, shard, streamIdDstShardIdMap, ww)
+			publishToSubscribers(topic.TopicId, rows)
+		}
+	}
+}
+
+// cloneRowForSubscribers deep-copies a row's Tags/Fields slices, which
+// otherwise still alias ww.tagpools/ww.fieldpools. publishToSubscribers hands
+// rows to buffered channels read asynchronously by handleTopicStream, so
+// without this copy a slow subscriber can observe tag/field bytes a later,
+// unrelated write has already overwritten once ww is recycled through
+// PutWritePointsWork.
+func cloneRowForSubscribers(row influx.Row) influx.Row {
+	row.Tags = append([]influx.Tag(nil), row.Tags...)
+	row.Fields = append([]influx.Field(nil), row.Fields...)
+	return row
+}
+
 func (s *Server) MustClose() {
 	// Mark the server as stopping.
 	s.setIsStopping()
 	s.selectServer.Close()
 	s.insertServer.Close()
-
+	if s.debugServer != nil {
+		_ = s.debugServer.Close()
+	}
 }
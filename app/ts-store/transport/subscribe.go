@@ -0,0 +1,86 @@
+/*
+Copyright 2022 Huawei Cloud Computing Technologies Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"sync"
+
+	"github.com/openGemini/openGemini/lib/errno"
+	"github.com/openGemini/openGemini/lib/logger"
+	"github.com/openGemini/openGemini/open_src/vm/protoparser/influx"
+	"go.uber.org/zap"
+)
+
+// subscribeQueueLen bounds how far a subscriber may fall behind before it
+// is dropped rather than blocking the ingest path it's tailing.
+const subscribeQueueLen = 1024
+
+// topicSubscribers fans committed rows out to select-side tailers (e.g. a
+// continuous query or an external ETL job) without them polling for new
+// data. SubscribeTopic itself only hands back an in-process Go channel;
+// out-of-process tailers read it via the chunked HTTP stream in
+// topic_stream_handler.go instead of an RPC.
+var topicSubscribers = struct {
+	mu   sync.RWMutex
+	subs map[uint64][]chan []influx.Row
+}{subs: make(map[uint64][]chan []influx.Row)}
+
+// SubscribeTopic returns a channel of row batches committed to topicId, and
+// a cancel func that must be called once the subscriber is done reading.
+func SubscribeTopic(topicId uint64) (<-chan []influx.Row, func()) {
+	ch := make(chan []influx.Row, subscribeQueueLen)
+
+	topicSubscribers.mu.Lock()
+	topicSubscribers.subs[topicId] = append(topicSubscribers.subs[topicId], ch)
+	topicSubscribers.mu.Unlock()
+
+	cancel := func() {
+		topicSubscribers.mu.Lock()
+		defer topicSubscribers.mu.Unlock()
+		subs := topicSubscribers.subs[topicId]
+		for i, c := range subs {
+			if c == ch {
+				topicSubscribers.subs[topicId] = append(subs[:i], subs[i+1:]...)
+				close(ch)
+				break
+			}
+		}
+	}
+	return ch, cancel
+}
+
+// publishToSubscribers fans rows out to every live subscriber of topicId. A
+// subscriber that isn't keeping up has its batch dropped rather than
+// blocking the write path; SubscribeTopic callers are expected to read
+// promptly since there is no replay.
+func publishToSubscribers(topicId uint64, rows []influx.Row) {
+	topicSubscribers.mu.RLock()
+	subs := topicSubscribers.subs[topicId]
+	topicSubscribers.mu.RUnlock()
+	if len(subs) == 0 {
+		return
+	}
+
+	log := logger.NewLogger(errno.ModuleStorageEngine)
+	for _, ch := range subs {
+		select {
+		case ch <- rows:
+		default:
+			log.Error("topic subscriber queue full, dropping batch", zap.Uint64("topicId", topicId))
+		}
+	}
+}
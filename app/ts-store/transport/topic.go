@@ -0,0 +1,125 @@
+/*
+Copyright 2022 Huawei Cloud Computing Technologies Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"errors"
+	"hash/fnv"
+	"sync"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/encoding"
+	"github.com/openGemini/openGemini/lib/util"
+	"github.com/openGemini/openGemini/open_src/vm/protoparser/influx"
+)
+
+// TopicDescriptor replaces the old flat (streamId, dstShardId) pair: the
+// client only needs to know which topic a row belongs to and which of the
+// row's tags form the partition key, ts-store owns the topic->shard
+// mapping from there on.
+type TopicDescriptor struct {
+	TopicId            uint64
+	PartitionKeyFields []string
+}
+
+// topicRoute is the current shard set a topic fans out to.
+type topicRoute struct {
+	shards []uint64
+}
+
+// topicRoutingTable maps topics to their destination shards and can be
+// rebalanced at runtime (e.g. from a meta RPC when shards are added or
+// moved) without requiring ingest clients to be aware of the change.
+type topicRoutingTable struct {
+	mu     sync.RWMutex
+	routes map[uint64]*topicRoute
+}
+
+var topics = &topicRoutingTable{routes: make(map[uint64]*topicRoute)}
+
+// RebalanceTopic installs a new shard set for topicId. It is meant to be
+// called from the meta RPC handler that owns shard placement; ts-store
+// itself has no opinion on how the shard set was chosen.
+func RebalanceTopic(topicId uint64, shards []uint64) {
+	topics.mu.Lock()
+	defer topics.mu.Unlock()
+	topics.routes[topicId] = &topicRoute{shards: shards}
+}
+
+// resolveShard computes the destination shard for row within topicId by
+// hashing the row's partition key fields (looked up among its tags) over
+// the topic's current shard set.
+func resolveShard(topicId uint64, fields []string, row *influx.Row) (uint64, bool) {
+	topics.mu.RLock()
+	route, ok := topics.routes[topicId]
+	topics.mu.RUnlock()
+	if !ok || len(route.shards) == 0 {
+		return 0, false
+	}
+
+	h := fnv.New64a()
+	for _, field := range fields {
+		for i := range row.Tags {
+			if row.Tags[i].Key == field {
+				_, _ = h.Write([]byte(row.Tags[i].Value))
+			}
+		}
+	}
+
+	return route.shards[h.Sum64()%uint64(len(route.shards))], true
+}
+
+// decodeTopicDescriptors parses the wire-format topic descriptor list that
+// replaced the old flat (streamId, dstShardId) pair-list: a uint32 count,
+// then per topic a uint64 topic id and a length-prefixed list of
+// length-prefixed partition key field names.
+func decodeTopicDescriptors(tail []byte) ([]TopicDescriptor, []byte, error) {
+	if len(tail) < 4 {
+		return nil, tail, errors.New("no data for topic descriptor list")
+	}
+	count := encoding.UnmarshalUint32(tail)
+	tail = tail[4:]
+
+	descs := make([]TopicDescriptor, 0, count)
+	for i := uint32(0); i < count; i++ {
+		if len(tail) < 9 {
+			return nil, tail, errors.New("truncated topic descriptor")
+		}
+		topicId := encoding.UnmarshalUint64(tail)
+		tail = tail[8:]
+
+		fieldCount := int(tail[0])
+		tail = tail[1:]
+
+		fields := make([]string, 0, fieldCount)
+		for j := 0; j < fieldCount; j++ {
+			if len(tail) < 1 {
+				return nil, tail, errors.New("truncated partition key field")
+			}
+			l := int(tail[0])
+			tail = tail[1:]
+			if len(tail) < l {
+				return nil, tail, errors.New("truncated partition key field")
+			}
+			fields = append(fields, util.Bytes2str(tail[:l]))
+			tail = tail[l:]
+		}
+
+		descs = append(descs, TopicDescriptor{TopicId: topicId, PartitionKeyFields: fields})
+	}
+
+	return descs, tail, nil
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2022 Huawei Cloud Computing Technologies Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// topicStreamPrefix is the path prefix handled by handleTopicStream;
+// requests are shaped /topics/{id}/subscribe.
+const topicStreamPrefix = "/topics/"
+
+// handleTopicStream lets an out-of-process tailer (a continuous query
+// runner, an external ETL job) follow a topic over a long-lived HTTP
+// response instead of needing to be in the same process as SubscribeTopic's
+// caller: each committed row batch is written as one newline-delimited JSON
+// array and flushed immediately.
+func handleTopicStream(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	topicId, ok := parseTopicStreamPath(r.URL.Path)
+	if !ok {
+		http.Error(w, "expected /topics/{id}/subscribe", http.StatusBadRequest)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	rows, cancel := SubscribeTopic(topicId)
+	defer cancel()
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	enc := json.NewEncoder(w)
+	for {
+		select {
+		case batch, open := <-rows:
+			if !open {
+				return
+			}
+			if err := enc.Encode(batch); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// parseTopicStreamPath extracts the topic id from /topics/{id}/subscribe.
+func parseTopicStreamPath(path string) (uint64, bool) {
+	const suffix = "/subscribe"
+
+	trimmed := strings.TrimPrefix(path, topicStreamPrefix)
+	if trimmed == path || !strings.HasSuffix(trimmed, suffix) {
+		return 0, false
+	}
+	idStr := strings.TrimSuffix(trimmed, suffix)
+
+	topicId, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return topicId, true
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2022 Huawei Cloud Computing Technologies Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"testing"
+
+	"github.com/openGemini/openGemini/open_src/vm/protoparser/influx"
+)
+
+func TestResolveShardNoRoute(t *testing.T) {
+	if _, ok := resolveShard(9999999, []string{"host"}, &influx.Row{}); ok {
+		t.Fatal("expected no route for an unregistered topic")
+	}
+}
+
+func TestResolveShardStable(t *testing.T) {
+	const topicId = 123
+	RebalanceTopic(topicId, []uint64{10, 20, 30})
+
+	row := &influx.Row{Tags: []influx.Tag{{Key: "host", Value: "server-1"}}}
+
+	first, ok := resolveShard(topicId, []string{"host"}, row)
+	if !ok {
+		t.Fatal("expected a route after RebalanceTopic")
+	}
+	second, ok := resolveShard(topicId, []string{"host"}, row)
+	if !ok || second != first {
+		t.Fatalf("resolveShard not stable for identical input: %d != %d", first, second)
+	}
+}
+
+func TestCloneRowForSubscribersIsIndependentOfSource(t *testing.T) {
+	tags := []influx.Tag{{Key: "host", Value: "server-1"}}
+	fields := []influx.Field{{Key: "value", NumValue: 1}}
+	row := influx.Row{Tags: tags, Fields: fields}
+
+	clone := cloneRowForSubscribers(row)
+
+	tags[0].Value = "overwritten"
+	fields[0].NumValue = 2
+	if clone.Tags[0].Value != "server-1" {
+		t.Fatalf("clone.Tags observed a mutation of the source slice: got %q", clone.Tags[0].Value)
+	}
+	if clone.Fields[0].NumValue != 1 {
+		t.Fatalf("clone.Fields observed a mutation of the source slice: got %v", clone.Fields[0].NumValue)
+	}
+}
+
+func TestParseTopicStreamPath(t *testing.T) {
+	cases := []struct {
+		path   string
+		wantId uint64
+		wantOk bool
+	}{
+		{"/topics/42/subscribe", 42, true},
+		{"/topics/42", 0, false},
+		{"/topics/abc/subscribe", 0, false},
+		{"/other/42/subscribe", 0, false},
+	}
+	for _, tc := range cases {
+		id, ok := parseTopicStreamPath(tc.path)
+		if ok != tc.wantOk || (ok && id != tc.wantId) {
+			t.Errorf("parseTopicStreamPath(%q) = (%d, %v), want (%d, %v)", tc.path, id, ok, tc.wantId, tc.wantOk)
+		}
+	}
+}
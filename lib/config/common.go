@@ -0,0 +1,73 @@
+/*
+Copyright 2022 Huawei Cloud Computing Technologies Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import "time"
+
+// Common holds process-wide ts-store settings that don't belong to any one
+// subsystem's own config block.
+type Common struct {
+	// Replication turns on quorum-aware replica writes; when false,
+	// WritePoints only ever writes to the local shard.
+	Replication bool
+
+	// MaxInflightWrites bounds how many WritePoints calls may be admitted
+	// at once before new writes block waiting for a slot.
+	MaxInflightWrites int
+	// MaxInflightBytes bounds the total request size of admitted writes;
+	// zero or negative disables the byte budget.
+	MaxInflightBytes int64
+
+	// HintedHandoffQueueMaxItems bounds the in-memory hinted handoff
+	// queue before writes spill to HintedHandoffQueueDir.
+	HintedHandoffQueueMaxItems int
+	// HintedHandoffQueueDir is where hinted handoff writes spill once the
+	// in-memory queue is full.
+	HintedHandoffQueueDir string
+
+	// ReplicaWriteTimeout bounds how long writeQuorum waits for replica
+	// acks before queuing the remainder for hinted handoff.
+	ReplicaWriteTimeout time.Duration
+
+	// DebugListenAddr, if set, serves operator debug endpoints (e.g.
+	// PUT /debug/log-level) on this address.
+	DebugListenAddr string
+}
+
+var common = Common{
+	MaxInflightWrites:          64,
+	MaxInflightBytes:           256 << 20,
+	HintedHandoffQueueMaxItems: 10000,
+	HintedHandoffQueueDir:      "/var/lib/openGemini/hh",
+	ReplicaWriteTimeout:        5 * time.Second,
+}
+
+// GetCommon returns the process-wide Common settings.
+func GetCommon() *Common {
+	return &common
+}
+
+// SetCommon replaces the process-wide Common settings, e.g. once the
+// on-disk config file has been parsed.
+func SetCommon(c Common) {
+	common = c
+}
+
+// IsReplication reports whether quorum-aware replica writes are enabled.
+func IsReplication() bool {
+	return common.Replication
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2022 Huawei Cloud Computing Technologies Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// AppSingle identifies the single-node (non-clustered) ts-store process, as
+// opposed to the ts-meta/ts-sql apps, which each have their own log file
+// naming.
+const AppSingle = "ts-single"
+
+// Logger holds everything needed to build the process-wide zap logger.
+type Logger struct {
+	Path       string
+	Level      zapcore.Level
+	Format     string
+	Dev        bool
+	RemoteSink *RemoteSink
+
+	app string
+}
+
+// NewLogger returns the default Logger configuration for app (one of
+// AppSingle or an app-specific name), ready to be overridden by the parsed
+// config file before InitLogger is called.
+func NewLogger(app string) Logger {
+	return Logger{
+		Path:   "/var/log/openGemini",
+		Level:  zapcore.InfoLevel,
+		Format: "json",
+		app:    app,
+	}
+}
+
+// GetApp returns the log file base name for this Logger's app.
+func (l Logger) GetApp() string {
+	return l.app
+}
+
+// NewLumberjackLogger returns a rotating file sink for fileName under the
+// configured log path.
+func (l Logger) NewLumberjackLogger(fileName string) *lumberjack.Logger {
+	return &lumberjack.Logger{
+		Filename:   fmt.Sprintf("%s/%s.log", l.Path, fileName),
+		MaxSize:    256, // megabytes
+		MaxBackups: 10,
+		MaxAge:     7, // days
+		Compress:   true,
+	}
+}
+
+// RemoteSink is an optional secondary log destination (e.g. a syslog or log
+// aggregator endpoint) tee'd alongside the local on-disk hooks.
+type RemoteSink struct {
+	Addr        string
+	Proto       string // "tcp" or "udp"; defaults to "tcp"
+	Level       zapcore.Level
+	DialTimeout time.Duration
+}
+
+// Network returns the dial network for this sink, defaulting to "tcp".
+func (s *RemoteSink) Network() string {
+	if s.Proto == "" {
+		return "tcp"
+	}
+	return s.Proto
+}
+
+// Timeout returns the dial timeout for this sink, defaulting to 5 seconds.
+func (s *RemoteSink) Timeout() time.Duration {
+	if s.DialTimeout <= 0 {
+		return 5 * time.Second
+	}
+	return s.DialTimeout
+}
@@ -18,6 +18,7 @@ package logger
 
 import (
 	"fmt"
+	"sync"
 
 	"github.com/openGemini/openGemini/lib/config"
 	"github.com/openGemini/openGemini/lib/crypto"
@@ -33,7 +34,29 @@ var hooks []*lumberjack.Logger
 
 var initHandler func(*zap.Logger)
 
-var level zapcore.Level
+// atomicLevel backs the process-wide level so it can be changed at runtime
+// (e.g. via the ts-store SetLevel RPC) without rebuilding the logger.
+var atomicLevel = zap.NewAtomicLevel()
+
+// baseCore is the tee built from the on-disk hooks, kept around so
+// RegisterCore can re-tee it with extra sinks without discarding hooks.
+var baseCore zapcore.Core
+
+// extraCores are sinks attached via RegisterCore, e.g. a slow-query audit
+// tee, kept separate from baseCore so they survive an InitLogger rebuild.
+var extraCores []zapcore.Core
+
+// devMode mirrors the configured Logger.Dev, so RegisterCore can rebuild the
+// logger with the same zap.Development() behavior getLogger used instead of
+// always enabling it, which would turn DPanic into a process panic on a
+// production node.
+var devMode bool
+
+var coreMu sync.Mutex
+
+// moduleLevels holds per-module level overrides keyed by errno.Module*, so
+// e.g. the write path can be silenced while meta stays at debug.
+var moduleLevels sync.Map // map[string]zapcore.Level
 
 func init() {
 	InitLogger(config.NewLogger(config.AppSingle))
@@ -44,7 +67,7 @@ func SetInitLoggerHandler(handler func(*zap.Logger)) {
 }
 
 func InitLogger(conf config.Logger) {
-	level = conf.Level
+	atomicLevel.SetLevel(rewriteLevel(conf.Level))
 	logger = getLogger(conf)
 	if initHandler != nil {
 		initHandler(logger)
@@ -64,6 +87,58 @@ func SetLogger(zapLogger *zap.Logger) {
 	}
 }
 
+// AtomicLevel returns the process-wide atomic level, e.g. to wire into the
+// ts-store SetLevel RPC handler so operators can bump verbosity on a live
+// node without a restart.
+func AtomicLevel() zap.AtomicLevel {
+	return atomicLevel
+}
+
+// SetLevel changes the process-wide log level in place.
+func SetLevel(lvl zapcore.Level) {
+	atomicLevel.SetLevel(rewriteLevel(lvl))
+}
+
+// SetModuleLevel overrides the effective level for a single module (keyed by
+// errno.Module*), e.g. to silence the noisy write path while meta stays at
+// debug. Passing a level outside [Debug, Fatal] clears the override. An
+// override below the process-wide level does not make the module's lower
+// entries reach the on-disk log, whose core still filters by that level.
+func SetModuleLevel(module string, lvl zapcore.Level) {
+	if lvl < zap.DebugLevel || lvl > zap.FatalLevel {
+		moduleLevels.Delete(module)
+		return
+	}
+	moduleLevels.Store(module, lvl)
+}
+
+// ModuleLevel returns the override for module, if one has been set.
+func ModuleLevel(module string) (zapcore.Level, bool) {
+	v, ok := moduleLevels.Load(module)
+	if !ok {
+		return 0, false
+	}
+	return v.(zapcore.Level), true
+}
+
+// RegisterCore tees an extra zapcore.Core onto the process logger, e.g. a
+// slow-query audit sink, without subsystems needing to patch global state.
+func RegisterCore(core zapcore.Core) {
+	coreMu.Lock()
+	extraCores = append(extraCores, core)
+	cores := append([]zapcore.Core{baseCore}, extraCores...)
+	opts := []zap.Option{zap.AddCaller()}
+	if devMode {
+		opts = append(opts, zap.Development())
+	}
+	coreMu.Unlock()
+
+	logger = zap.New(zapcore.NewTee(cores...), opts...)
+	if initHandler != nil {
+		initHandler(logger)
+	}
+}
+
 func CloseLogger() {
 	_ = logger.Sync()
 	closeHooks()
@@ -74,21 +149,34 @@ func getLogger(conf config.Logger) *zap.Logger {
 	hookError := conf.NewLumberjackLogger(makeErrFileName(conf.GetApp()))
 	hooks = append(hooks, hookNormal, hookError)
 
-	encoder := newEncoder()
+	encoder := newEncoder(conf.Format)
 
-	logLevel := rewriteLevel(conf.Level)
 	levelNormal := zap.LevelEnablerFunc(func(lvl zapcore.Level) bool {
-		return lvl >= logLevel
+		return lvl >= atomicLevel.Level()
 	})
 	levelError := zap.LevelEnablerFunc(func(lvl zapcore.Level) bool {
 		return lvl >= zapcore.ErrorLevel
 	})
-	core := zapcore.NewTee(
+	cores := []zapcore.Core{
 		zapcore.NewCore(encoder, zapcore.AddSync(hookNormal), levelNormal),
 		zapcore.NewCore(encoder, zapcore.AddSync(hookError), levelError),
-	)
+	}
+	if sink := newRemoteSinkCore(conf.RemoteSink, encoder); sink != nil {
+		cores = append(cores, sink)
+	}
+
+	coreMu.Lock()
+	baseCore = zapcore.NewTee(cores...)
+	allCores := append([]zapcore.Core{baseCore}, extraCores...)
+	devMode = conf.Dev
+	coreMu.Unlock()
 
-	return zap.New(core, zap.AddCaller(), zap.Development())
+	opts := []zap.Option{zap.AddCaller()}
+	if conf.Dev {
+		opts = append(opts, zap.Development())
+	}
+
+	return zap.New(zapcore.NewTee(allCores...), opts...)
 }
 
 func rewriteLevel(level zapcore.Level) zapcore.Level {
@@ -113,7 +201,7 @@ func closeHooks() {
 	hooks = nil
 }
 
-func newEncoder() zapcore.Encoder {
+func newEncoder(format string) zapcore.Encoder {
 	// log format
 	encoderConfig := zapcore.EncoderConfig{
 		TimeKey:        "time",
@@ -130,5 +218,8 @@ func newEncoder() zapcore.Encoder {
 		EncodeName:     zapcore.FullNameEncoder,
 	}
 
+	if format == "text" {
+		return zapcore.NewConsoleEncoder(encoderConfig)
+	}
 	return zapcore.NewJSONEncoder(encoderConfig)
 }
@@ -0,0 +1,81 @@
+/*
+Copyright 2022 Huawei Cloud Computing Technologies Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logger
+
+import (
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestRegisterCoreRespectsConfiguredDevFlag(t *testing.T) {
+	prevDev := devMode
+	defer func() { devMode = prevDev }()
+
+	devMode = false
+	RegisterCore(zapcore.NewNopCore())
+	defer func() {
+		coreMu.Lock()
+		extraCores = nil
+		coreMu.Unlock()
+	}()
+
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				t.Fatal("DPanic should not panic when the logger was not built in dev mode")
+			}
+		}()
+		logger.DPanic("test dpanic")
+	}()
+}
+
+func TestSetModuleLevelOverridesGlobal(t *testing.T) {
+	const module = "test-module"
+	defer SetModuleLevel(module, zapcore.Level(-99)) // clear override afterwards
+
+	prev := atomicLevel.Level()
+	defer atomicLevel.SetLevel(prev)
+	atomicLevel.SetLevel(zapcore.ErrorLevel)
+
+	l := NewLogger(module)
+	if l.enabled(zapcore.InfoLevel) {
+		t.Fatal("info should be disabled under the global error level before any override")
+	}
+
+	SetModuleLevel(module, zapcore.DebugLevel)
+	if !l.enabled(zapcore.InfoLevel) {
+		t.Fatal("info should be enabled once the module override is set to debug")
+	}
+
+	if _, ok := ModuleLevel(module); !ok {
+		t.Fatal("ModuleLevel should report the override that was just set")
+	}
+}
+
+func TestSetModuleLevelClearsOnInvalidLevel(t *testing.T) {
+	const module = "test-module-clear"
+	SetModuleLevel(module, zapcore.WarnLevel)
+	if _, ok := ModuleLevel(module); !ok {
+		t.Fatal("expected override to be set")
+	}
+
+	SetModuleLevel(module, zapcore.Level(-99))
+	if _, ok := ModuleLevel(module); ok {
+		t.Fatal("expected an out-of-range level to clear the override")
+	}
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2022 Huawei Cloud Computing Technologies Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logger
+
+import (
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Logger is a module-scoped logger, keyed by one of errno.Module*. Its
+// entries are gated by SetModuleLevel's override for that module when one
+// is set, and fall back to the process-wide atomic level otherwise, so
+// e.g. the write path can be silenced while meta stays at debug. Entries
+// that pass the override are still written through the process logger,
+// whose on-disk core drops anything below the process-wide level, so an
+// override can only make a module quieter than that level, not louder.
+type Logger struct {
+	module string
+}
+
+// NewLogger returns a Logger scoped to module.
+func NewLogger(module string) *Logger {
+	return &Logger{module: module}
+}
+
+func (l *Logger) Debug(msg string, fields ...zap.Field) {
+	l.log(zapcore.DebugLevel, msg, fields)
+}
+
+func (l *Logger) Info(msg string, fields ...zap.Field) {
+	l.log(zapcore.InfoLevel, msg, fields)
+}
+
+func (l *Logger) Warn(msg string, fields ...zap.Field) {
+	l.log(zapcore.WarnLevel, msg, fields)
+}
+
+func (l *Logger) Error(msg string, fields ...zap.Field) {
+	l.log(zapcore.ErrorLevel, msg, fields)
+}
+
+func (l *Logger) Fatal(msg string, fields ...zap.Field) {
+	l.log(zapcore.FatalLevel, msg, fields)
+}
+
+// enabled reports whether lvl should be logged for this Logger's module,
+// consulting the per-module override installed by SetModuleLevel before
+// falling back to the process-wide level.
+func (l *Logger) enabled(lvl zapcore.Level) bool {
+	if override, ok := ModuleLevel(l.module); ok {
+		return lvl >= override
+	}
+	return lvl >= atomicLevel.Level()
+}
+
+func (l *Logger) log(lvl zapcore.Level, msg string, fields []zap.Field) {
+	if !l.enabled(lvl) {
+		return
+	}
+	zl := GetLogger()
+	if zl == nil {
+		return
+	}
+	named := zl.Named(l.module)
+	switch lvl {
+	case zapcore.DebugLevel:
+		named.Debug(msg, fields...)
+	case zapcore.InfoLevel:
+		named.Info(msg, fields...)
+	case zapcore.WarnLevel:
+		named.Warn(msg, fields...)
+	case zapcore.ErrorLevel:
+		named.Error(msg, fields...)
+	case zapcore.FatalLevel:
+		named.Fatal(msg, fields...)
+	}
+}
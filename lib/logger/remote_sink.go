@@ -0,0 +1,50 @@
+/*
+Copyright 2022 Huawei Cloud Computing Technologies Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logger
+
+import (
+	"fmt"
+	"net"
+	"os"
+
+	"github.com/openGemini/openGemini/lib/config"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// newRemoteSinkCore dials the configured remote sink (syslog/OTLP/Kafka
+// endpoint) and wraps it in a core tee'd alongside the local lumberjack
+// hooks. A nil or unset RemoteSink disables it. Dial failures are logged to
+// stderr and otherwise ignored, a missing audit sink is not worth bringing a
+// node down over.
+func newRemoteSinkCore(sink *config.RemoteSink, encoder zapcore.Encoder) zapcore.Core {
+	if sink == nil || sink.Addr == "" {
+		return nil
+	}
+
+	conn, err := net.DialTimeout(sink.Network(), sink.Addr, sink.Timeout())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "logger: remote sink %q unreachable, continuing without it: %s\n", sink.Addr, err)
+		return nil
+	}
+
+	level := rewriteLevel(sink.Level)
+	enabler := zap.LevelEnablerFunc(func(lvl zapcore.Level) bool {
+		return lvl >= level
+	})
+	return zapcore.NewCore(encoder, zapcore.AddSync(conn), enabler)
+}